@@ -0,0 +1,136 @@
+// Package auth performs local authentication checks (JWT signature
+// verification against a cached JWKS, and API key validation result
+// caching) so the proxy's hot path doesn't need a synchronous HTTP hop
+// to the Node auth service on every request.
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksRefreshInterval is how often a cached JWKS document is refetched
+// even if no unknown kid has been seen.
+const jwksRefreshInterval = 10 * time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKSCache fetches and caches RSA public keys from a JWKS endpoint,
+// keyed by "kid". It transparently refetches the document when asked
+// for a kid it doesn't recognize, and otherwise at most every
+// jwksRefreshInterval.
+type JWKSCache struct {
+	url        string
+	httpClient *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	lastFetched time.Time
+}
+
+// NewJWKSCache creates a cache that fetches keys from jwksURL on demand.
+func NewJWKSCache(jwksURL string) *JWKSCache {
+	return &JWKSCache{
+		url:        jwksURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		keys:       make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Key returns the RSA public key for kid, refreshing the JWKS document
+// first if kid is unknown or the cache is older than
+// jwksRefreshInterval.
+func (c *JWKSCache) Key(kid string) (*rsa.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	stale := time.Since(c.lastFetched) > jwksRefreshInterval
+	c.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := c.refresh(); err != nil {
+		if ok {
+			// Serve the stale key rather than fail outright on a
+			// transient JWKS endpoint outage.
+			return key, nil
+		}
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("auth: unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (c *JWKSCache) refresh() error {
+	resp, err := c.httpClient.Get(c.url)
+	if err != nil {
+		return fmt.Errorf("auth: fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth: JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("auth: decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(k)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.lastFetched = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+func rsaPublicKeyFromJWK(k jwk) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decoding modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decoding exponent: %w", err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
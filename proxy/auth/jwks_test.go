@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestRSAPublicKeyFromJWK(t *testing.T) {
+	k := jwk{
+		Kty: "RSA",
+		Kid: "test-key",
+		N:   base64.RawURLEncoding.EncodeToString([]byte{0x01, 0x00, 0x01, 0xAB}),
+		E:   base64.RawURLEncoding.EncodeToString([]byte{0x01, 0x00, 0x01}),
+	}
+
+	pub, err := rsaPublicKeyFromJWK(k)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pub.E != 65537 {
+		t.Fatalf("expected standard RSA exponent 65537, got %d", pub.E)
+	}
+}
+
+func TestJWKSCacheUnknownKidErrorsWhenEndpointUnreachable(t *testing.T) {
+	c := NewJWKSCache("http://127.0.0.1:0/jwks.json")
+	if _, err := c.Key("missing"); err == nil {
+		t.Fatalf("expected an error for an unreachable JWKS endpoint")
+	}
+}
@@ -0,0 +1,192 @@
+// Package executor forwards proxied requests to the executor service
+// behind a per-API circuit breaker with bounded, jittered retries, so a
+// slow or down executor can't pile up goroutines or block the rest of
+// the gateway.
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+
+	"github.com/vatan45/Decentralised-api-gateway/proxy/telemetry"
+)
+
+// maxAttempts is the initial attempt plus at most two retries.
+const maxAttempts = 3
+
+// breakerFailureThreshold trips the breaker after this many consecutive
+// failures for an API.
+const breakerFailureThreshold = 5
+
+// breakerOpenTimeout is how long the breaker stays open before probing
+// the executor again (half-open).
+const breakerOpenTimeout = 30 * time.Second
+
+// CircuitOpenError is returned when an API's circuit breaker is open
+// (or limiting half-open probes), so the proxy can fast-fail with a 503
+// instead of waiting on a downed executor.
+type CircuitOpenError struct {
+	APIID      string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("executor: circuit breaker open for API %s", e.APIID)
+}
+
+// upstreamStatusError wraps a non-transport 5xx response from the
+// executor. It still counts as a breaker failure and is still
+// retried for idempotent methods, but it carries the real status,
+// headers, and body so that - once retries are exhausted - Do can
+// hand the genuine upstream response back to the caller instead of a
+// synthetic error.
+type upstreamStatusError struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("executor responded with status %d", e.status)
+}
+
+func (e *upstreamStatusError) response() *http.Response {
+	return &http.Response{
+		StatusCode:    e.status,
+		Header:        e.header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+	}
+}
+
+// idempotentMethods are safe to retry without risking duplicate
+// side effects on the executor.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// Forwarder sends requests to the executor, keeping one circuit breaker
+// per API ID.
+type Forwarder struct {
+	client   *http.Client
+	breakers sync.Map // apiID -> *gobreaker.CircuitBreaker
+}
+
+// NewForwarder creates a Forwarder using client to reach the executor.
+func NewForwarder(client *http.Client) *Forwarder {
+	return &Forwarder{client: client}
+}
+
+func (f *Forwarder) breakerFor(apiID string) *gobreaker.CircuitBreaker {
+	if b, ok := f.breakers.Load(apiID); ok {
+		return b.(*gobreaker.CircuitBreaker)
+	}
+
+	settings := gobreaker.Settings{
+		Name:        apiID,
+		MaxRequests: 1,
+		Interval:    60 * time.Second,
+		Timeout:     breakerOpenTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= breakerFailureThreshold
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Printf("executor: circuit breaker %q transitioned %s -> %s", name, from, to)
+			if to == gobreaker.StateOpen {
+				telemetry.CircuitBreakerTrips.WithLabelValues(name).Inc()
+			}
+		},
+	}
+
+	b := gobreaker.NewCircuitBreaker(settings)
+	actual, _ := f.breakers.LoadOrStore(apiID, b)
+	return actual.(*gobreaker.CircuitBreaker)
+}
+
+// Do executes a request to the executor for apiID, via buildRequest
+// (called once per attempt so retries get a fresh body/headers), behind
+// that API's circuit breaker. GET/HEAD/PUT/DELETE requests are retried
+// up to two times with exponential backoff and jitter on a 5xx response
+// or transport error; other methods are attempted once.
+func (f *Forwarder) Do(apiID, method string, buildRequest func() (*http.Request, error)) (*http.Response, error) {
+	breaker := f.breakerFor(apiID)
+
+	attempts := 1
+	if idempotentMethods[method] {
+		attempts = maxAttempts
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffWithJitter(attempt))
+		}
+
+		req, err := buildRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := breaker.Execute(func() (interface{}, error) {
+			resp, err := f.client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode >= 500 {
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				return nil, &upstreamStatusError{status: resp.StatusCode, header: resp.Header, body: body}
+			}
+			return resp, nil
+		})
+
+		if err == nil {
+			return result.(*http.Response), nil
+		}
+
+		if err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests {
+			return nil, &CircuitOpenError{APIID: apiID, RetryAfter: breakerOpenTimeout}
+		}
+
+		lastErr = err
+
+		var statusErr *upstreamStatusError
+		if errors.As(err, &statusErr) && attempt == attempts-1 {
+			return statusErr.response(), nil
+		}
+	}
+
+	return nil, lastErr
+}
+
+// backoffWithJitter returns an exponentially increasing delay
+// (100ms, 200ms, 400ms, ...) for the given 1-indexed retry attempt,
+// plus up to 50% random jitter to avoid thundering-herd retries.
+func backoffWithJitter(attempt int) time.Duration {
+	base := time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
+	jitter := time.Duration(rand.Int63n(int64(base)/2 + 1))
+	return base + jitter
+}
+
+// States returns the current circuit breaker state for every API that
+// has forwarded at least one request, for exposing on /health.
+func (f *Forwarder) States() map[string]string {
+	states := make(map[string]string)
+	f.breakers.Range(func(key, value interface{}) bool {
+		states[key.(string)] = value.(*gobreaker.CircuitBreaker).State().String()
+		return true
+	})
+	return states
+}
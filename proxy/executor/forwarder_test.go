@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDoRetriesIdempotentMethodOn5xx(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f := NewForwarder(server.Client())
+	resp, err := f.Do("api-1", http.MethodGet, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if attempts < 2 {
+		t.Fatalf("expected at least one retry, got %d attempt(s)", attempts)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected final attempt to succeed, got status %d", resp.StatusCode)
+	}
+}
+
+func TestDoDoesNotRetryNonIdempotentMethod(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("upstream failure"))
+	}))
+	defer server.Close()
+
+	f := NewForwarder(server.Client())
+	resp, err := f.Do("api-2", http.MethodPost, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, server.URL, nil)
+	})
+	if err != nil {
+		t.Fatalf("expected the genuine upstream response, got error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the upstream's real status to pass through, got %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "upstream failure" {
+		t.Fatalf("expected the upstream's real body to pass through, got %q", body)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected exactly one attempt for a non-idempotent method, got %d", attempts)
+	}
+}
+
+func TestStatesReflectsKnownAPIs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f := NewForwarder(server.Client())
+	_, err := f.Do("api-3", http.MethodGet, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	states := f.States()
+	if _, ok := states["api-3"]; !ok {
+		t.Fatalf("expected a breaker state for api-3, got %v", states)
+	}
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -15,6 +21,14 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/vatan45/Decentralised-api-gateway/proxy/auth"
+	"github.com/vatan45/Decentralised-api-gateway/proxy/executor"
+	"github.com/vatan45/Decentralised-api-gateway/proxy/middleware"
+	"github.com/vatan45/Decentralised-api-gateway/proxy/registry"
+	"github.com/vatan45/Decentralised-api-gateway/proxy/router"
+	"github.com/vatan45/Decentralised-api-gateway/proxy/services"
+	"github.com/vatan45/Decentralised-api-gateway/proxy/telemetry"
 )
 
 // API represents the API model from MongoDB
@@ -27,52 +41,81 @@ type API struct {
 	Endpoints      []Endpoint `bson:"endpoints" json:"endpoints"`
 	CurrentVersion string     `bson:"currentVersion" json:"currentVersion"`
 	IsPublic       bool       `bson:"isPublic" json:"isPublic"`
+	RateLimit      RateLimit  `bson:"rateLimit" json:"rateLimit"`
 	CreatedAt      time.Time  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt      time.Time  `bson:"updatedAt" json:"updatedAt"`
 }
 
 // Endpoint represents an API endpoint
 type Endpoint struct {
-	Path        string `bson:"path" json:"path"`
-	Method      string `bson:"method" json:"method"`
+	Path   string `bson:"path" json:"path"`
+	Method string `bson:"method" json:"method"`
+	// Host restricts this endpoint to a specific request host when set,
+	// enabling host-based routing alongside path matching. Empty matches
+	// any host.
+	Host        string `bson:"host,omitempty" json:"host,omitempty"`
 	Price       int    `bson:"price" json:"price"`
 	IsEnabled   bool   `bson:"isEnabled" json:"isEnabled"`
 	Description string `bson:"description" json:"description"`
 }
 
-// RequestLog represents a logged request
-type RequestLog struct {
-	UserID       string    `json:"user_id"`
-	APIID        string    `json:"api_id"`
-	Endpoint     string    `json:"endpoint"`
-	Method       string    `json:"method"`
-	IP           string    `json:"ip"`
-	Timestamp    time.Time `json:"timestamp"`
-	Status       int       `json:"status"`
-	ResponseTime int64     `json:"response_time"`
+// RateLimit is a per-API rate and quota configuration. A zero value in
+// any field means that dimension is unlimited.
+type RateLimit struct {
+	RequestsPerMinute int `bson:"requestsPerMinute" json:"requestsPerMinute"`
+	RequestsPerDay    int `bson:"requestsPerDay" json:"requestsPerDay"`
+	MonthlyCredits    int `bson:"monthlyCredits" json:"monthlyCredits"`
 }
 
 // Config holds application configuration
 type Config struct {
-	MongoURI    string
-	RedisURI    string
-	ExecutorURL string
-	Port        string
+	MongoURI     string
+	RedisURI     string
+	ExecutorURL  string
+	Port         string
+	JWKSURL      string
+	JWTIssuer    string
+	JWTAudience  string
+	OTLPEndpoint string
+	AdminToken   string
 }
 
+// apiKeyCacheTTL bounds how long a validated API key's user ID is cached
+// in Redis before the Node auth service is consulted again.
+const apiKeyCacheTTL = 60 * time.Second
+
 var (
-	config      Config
-	mongoClient *mongo.Client
-	redisClient *redis.Client
+	config         Config
+	mongoClient    *mongo.Client
+	redisClient    *redis.Client
+	routerCache    = router.NewCache()
+	metadataStore  *registry.Registry
+	loggerService  *services.LoggerService
+	rateLimiter    *middleware.RateLimiter
+	jwtVerifier    *auth.Verifier
+	executorClient *executor.Forwarder
 )
 
 func main() {
 	// Load configuration
 	config = Config{
-		MongoURI:    getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		RedisURI:    getEnv("REDIS_URI", "redis://localhost:6379"),
-		ExecutorURL: getEnv("EXECUTOR_URL", "http://localhost:3001"),
-		Port:        getEnv("PROXY_PORT", "8080"),
+		MongoURI:     getEnv("MONGO_URI", "mongodb://localhost:27017"),
+		RedisURI:     getEnv("REDIS_URI", "redis://localhost:6379"),
+		ExecutorURL:  getEnv("EXECUTOR_URL", "http://localhost:3001"),
+		Port:         getEnv("PROXY_PORT", "8080"),
+		JWKSURL:      getEnv("JWKS_URL", ""),
+		JWTIssuer:    getEnv("JWT_ISSUER", ""),
+		JWTAudience:  getEnv("JWT_AUDIENCE", ""),
+		OTLPEndpoint: getEnv("OTLP_ENDPOINT", "localhost:4317"),
+		AdminToken:   getEnv("ADMIN_TOKEN", ""),
+	}
+
+	// Initialize OpenTelemetry tracing (best-effort: a missing collector
+	// shouldn't block the gateway from starting)
+	if shutdown, err := telemetry.InitTracer(context.Background(), "api-gateway-proxy", config.OTLPEndpoint); err != nil {
+		log.Printf("telemetry: failed to initialize tracer, continuing without tracing: %v", err)
+	} else {
+		defer shutdown(context.Background())
 	}
 
 	// Initialize MongoDB connection
@@ -83,19 +126,38 @@ func main() {
 	initRedis()
 	defer redisClient.Close()
 
-	// Setup Gin router
-	router := gin.Default()
+	// Initialize the in-memory API metadata registry
+	initMetadataRegistry()
+
+	// Initialize the batched analytics logger
+	loggerService = services.NewLoggerService(redisClient)
+	defer loggerService.Close()
+
+	// Initialize rate limiting and start the credit-usage reconciler
+	rateLimiter = middleware.NewRateLimiter(redisClient)
+	go runCreditReconciler(context.Background())
+
+	// Initialize local JWT verification, if a JWKS endpoint is configured
+	if config.JWKSURL != "" {
+		jwtVerifier = auth.NewVerifier(auth.NewJWKSCache(config.JWKSURL), config.JWTIssuer, config.JWTAudience)
+	}
+
+	// Initialize the circuit-breaking executor forwarder
+	executorClient = executor.NewForwarder(&http.Client{Timeout: 30 * time.Second})
+
+	// Setup Gin engine
+	engine := gin.Default()
 
 	// Add middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	engine.Use(gin.Logger())
+	engine.Use(gin.Recovery())
 
 	// Setup proxy routes
-	setupProxyRoutes(router)
+	setupProxyRoutes(engine)
 
 	// Start server
 	log.Printf("API Gateway Proxy starting on port %s", config.Port)
-	if err := router.Run(":" + config.Port); err != nil {
+	if err := engine.Run(":" + config.Port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
@@ -139,27 +201,111 @@ func initRedis() {
 	log.Println("Connected to Redis")
 }
 
-func setupProxyRoutes(router *gin.Engine) {
+func setupProxyRoutes(engine *gin.Engine) {
 	// Catch-all route for API proxying
-	router.Any("/api/:apiName/*path", proxyHandler)
+	engine.Any("/api/:apiName/*path", proxyHandler)
 
 	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "healthy"})
+	engine.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status":           "healthy",
+			"dropped_logs":     loggerService.DroppedCount(),
+			"circuit_breakers": executorClient.States(),
+		})
+	})
+
+	// Prometheus metrics endpoint
+	engine.GET("/metrics", gin.WrapH(telemetry.Handler()))
+
+	// Forces a full resync of the in-memory metadata registry from Mongo.
+	// Gated behind ADMIN_TOKEN (a shared secret, not per-user auth, since
+	// this is an operator/operations endpoint) so it can't be hammered
+	// by anonymous callers to force repeated full Mongo scans.
+	engine.POST("/admin/reload", requireAdminToken, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		if err := metadataStore.Resync(ctx); err != nil {
+			c.JSON(500, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		routerCache.InvalidateAll()
+		c.JSON(200, gin.H{"success": true})
 	})
 }
 
+// requireAdminToken gates operator-only endpoints behind the
+// ADMIN_TOKEN shared secret, supplied via the X-Admin-Token header. If
+// no token is configured the endpoint is disabled entirely rather than
+// left open.
+func requireAdminToken(c *gin.Context) {
+	if config.AdminToken == "" {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "admin endpoint disabled"})
+		c.Abort()
+		return
+	}
+
+	supplied := c.GetHeader("X-Admin-Token")
+	if subtle.ConstantTimeCompare([]byte(supplied), []byte(config.AdminToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "invalid admin token"})
+		c.Abort()
+		return
+	}
+}
+
+// initMetadataRegistry loads every API into an in-memory registry and
+// starts the background watchers that keep it current: a MongoDB change
+// stream on the `apis` collection, and a Redis pub/sub subscription on
+// registry.InvalidateChannel that other services (e.g. the Node auth
+// service) can publish to.
+func initMetadataRegistry() {
+	collection := mongoClient.Database("api_auth_service").Collection("apis")
+	metadataStore = registry.New(collection, redisClient)
+	metadataStore.OnInvalidate = routerCache.Invalidate
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := metadataStore.Resync(ctx); err != nil {
+		log.Printf("MetadataRegistry: initial resync failed, falling back to per-request Mongo reads: %v", err)
+	}
+
+	go metadataStore.WatchChangeStream(context.Background())
+	go metadataStore.WatchInvalidations(context.Background())
+}
+
 func proxyHandler(c *gin.Context) {
 	startTime := time.Now()
 
+	ctx, span := telemetry.Tracer().Start(c.Request.Context(), "proxyHandler")
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
+
 	apiName := c.Param("apiName")
 	path := c.Param("path")
 	method := c.Request.Method
 	clientIP := c.ClientIP()
 
+	var api *API
+	statusCode := 200
+	// endpointLabel is the endpoint's declared path pattern (e.g.
+	// "/users/:id"), not the literal resolved path - using the raw path
+	// as a metric label would create a new Prometheus time series per
+	// distinct resource ID.
+	endpointLabel := "unmatched"
+	defer func() {
+		apiID := apiName
+		if api != nil {
+			apiID = api.ID
+		}
+		telemetry.RequestDuration.WithLabelValues(apiID, endpointLabel, method, fmt.Sprintf("%d", statusCode)).
+			Observe(time.Since(startTime).Seconds())
+	}()
+
 	// Get API metadata from database
-	api, err := getAPIMetadata(apiName)
+	var err error
+	api, err = getAPIMetadata(ctx, apiName)
 	if err != nil {
+		statusCode = 404
 		c.JSON(404, gin.H{
 			"success": false,
 			"message": "API not found",
@@ -168,18 +314,23 @@ func proxyHandler(c *gin.Context) {
 	}
 
 	// Find matching endpoint
-	endpoint, found := findMatchingEndpoint(api, path, method)
+	endpoint, pathParams, found := findMatchingEndpoint(api, path, method, c.Request.Host)
 	if !found {
+		statusCode = 404
 		c.JSON(404, gin.H{
 			"success": false,
 			"message": "Endpoint not found",
 		})
 		return
 	}
+	endpointLabel = endpoint.Path
+	c.Set("pathParams", pathParams)
 
 	// Validate authentication
 	userID, err := validateAuth(c, api)
 	if err != nil {
+		statusCode = 401
+		telemetry.AuthFailures.WithLabelValues("unauthenticated").Inc()
 		c.JSON(401, gin.H{
 			"success": false,
 			"message": "Authentication required",
@@ -187,18 +338,60 @@ func proxyHandler(c *gin.Context) {
 		return
 	}
 
+	// Enforce rate limit and monthly credit quota
+	limits := middleware.Limits{
+		RequestsPerMinute: api.RateLimit.RequestsPerMinute,
+		RequestsPerDay:    api.RateLimit.RequestsPerDay,
+		MonthlyCredits:    api.RateLimit.MonthlyCredits,
+	}
+	rateLimitStart := time.Now()
+	result, err := rateLimiter.Allow(ctx, userID, api.ID, limits, endpoint.Price)
+	telemetry.RedisLatency.WithLabelValues("rate_limit_allow").Observe(time.Since(rateLimitStart).Seconds())
+	if err != nil {
+		statusCode = 500
+		c.JSON(500, gin.H{
+			"success": false,
+			"message": "Failed to evaluate rate limit",
+		})
+		return
+	}
+	c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", result.Remaining))
+	c.Header("X-RateLimit-Reset", fmt.Sprintf("%d", result.ResetSecs))
+	if !result.Allowed {
+		statusCode = 429
+		telemetry.RateLimitRejections.WithLabelValues(api.ID).Inc()
+		c.JSON(429, gin.H{
+			"success": false,
+			"message": "Rate limit or quota exceeded",
+		})
+		return
+	}
+
 	// Forward request to executor
 	response, err := forwardRequest(c, api, endpoint)
 	if err != nil {
+		var circuitOpen *executor.CircuitOpenError
+		if errors.As(err, &circuitOpen) {
+			statusCode = 503
+			c.Header("Retry-After", fmt.Sprintf("%d", int(circuitOpen.RetryAfter.Seconds())))
+			c.JSON(503, gin.H{
+				"success": false,
+				"message": "Executor temporarily unavailable",
+			})
+			return
+		}
+
+		statusCode = 500
 		c.JSON(500, gin.H{
 			"success": false,
 			"message": "Failed to forward request",
 		})
 		return
 	}
+	statusCode = response.StatusCode
 
-	// Log request
-	go logRequest(RequestLog{
+	// Log request (non-blocking: LoggerService batches and flushes async)
+	loggerService.LogRequest(services.RequestLog{
 		UserID:       userID,
 		APIID:        api.ID,
 		Endpoint:     path,
@@ -213,14 +406,25 @@ func proxyHandler(c *gin.Context) {
 	c.DataFromReader(response.StatusCode, response.ContentLength, response.Header.Get("Content-Type"), response.Body, nil)
 }
 
-func getAPIMetadata(apiName string) (*API, error) {
+func getAPIMetadata(ctx context.Context, apiName string) (*API, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "getAPIMetadata")
+	defer span.End()
+
+	// Fast path: served from the in-memory registry, no Mongo round trip
+	var api API
+	if found, err := metadataStore.Decode(apiName, &api); err == nil && found {
+		return &api, nil
+	}
+
+	// Slow path: registry miss, fall back to Mongo directly
 	collection := mongoClient.Database("api_auth_service").Collection("apis")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var api API
-	err := collection.FindOne(ctx, bson.M{"name": apiName}).Decode(&api)
+	mongoStart := time.Now()
+	err := collection.FindOne(queryCtx, bson.M{"name": apiName}).Decode(&api)
+	telemetry.MongoLatency.WithLabelValues("find_api").Observe(time.Since(mongoStart).Seconds())
 	if err != nil {
 		return nil, err
 	}
@@ -228,16 +432,40 @@ func getAPIMetadata(apiName string) (*API, error) {
 	return &api, nil
 }
 
-func findMatchingEndpoint(api *API, path, method string) (*Endpoint, bool) {
-	for _, endpoint := range api.Endpoints {
-		if endpoint.Path == path && endpoint.Method == method && endpoint.IsEnabled {
-			return &endpoint, true
+// findMatchingEndpoint resolves path/method (and optional host) against the
+// API's compiled route table, returning the matched endpoint along with any
+// extracted path parameters (e.g. {"id": "42"} for a "/users/:id" route).
+// The compiled table is cached per API so repeat hits skip recompilation;
+// the cache is invalidated whenever the API's metadata changes.
+func findMatchingEndpoint(api *API, path, method, host string) (*Endpoint, map[string]string, bool) {
+	r := routerCache.GetOrCompile(api.ID, func() []router.EndpointSource {
+		sources := make([]router.EndpointSource, 0, len(api.Endpoints))
+		for i := range api.Endpoints {
+			ep := api.Endpoints[i]
+			if !ep.IsEnabled {
+				continue
+			}
+			sources = append(sources, router.EndpointSource{
+				Method: ep.Method,
+				Path:   ep.Path,
+				Host:   ep.Host,
+				Data:   &ep,
+			})
 		}
+		return sources
+	})
+
+	data, params, found := r.Match(method, host, path)
+	if !found {
+		return nil, nil, false
 	}
-	return nil, false
+	return data.(*Endpoint), params, true
 }
 
 func validateAuth(c *gin.Context, api *API) (string, error) {
+	_, span := telemetry.Tracer().Start(c.Request.Context(), "validateAuth")
+	defer span.End()
+
 	// Check for API key in header
 	apiKey := c.GetHeader("X-API-Key")
 	if apiKey != "" {
@@ -259,9 +487,41 @@ func validateAuth(c *gin.Context, api *API) (string, error) {
 	return "", fmt.Errorf("authentication required")
 }
 
+// validateAPIKey validates apiKey against api, serving a cached user ID
+// out of Redis when available so repeated hits from the same key skip
+// the Node auth service round trip.
 func validateAPIKey(apiKey string, api *API) (string, error) {
-	// In a real implementation, you would validate the API key against your database
-	// For now, we'll make a request to your Node.js auth service
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	cacheKey := "auth:key:" + hashAPIKey(apiKey) + ":" + api.ID
+
+	getStart := time.Now()
+	cached, err := redisClient.Get(ctx, cacheKey).Result()
+	telemetry.RedisLatency.WithLabelValues("get_api_key").Observe(time.Since(getStart).Seconds())
+	if err == nil && cached != "" {
+		return cached, nil
+	}
+
+	userID, err := validateAPIKeyRemote(apiKey, api)
+	if err != nil {
+		return "", err
+	}
+
+	setStart := time.Now()
+	redisClient.Set(ctx, cacheKey, userID, apiKeyCacheTTL)
+	telemetry.RedisLatency.WithLabelValues("set_api_key").Observe(time.Since(setStart).Seconds())
+	return userID, nil
+}
+
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// validateAPIKeyRemote is the Node auth service fallback used on a
+// cache miss.
+func validateAPIKeyRemote(apiKey string, api *API) (string, error) {
 	url := "http://localhost:5000/api/auth/validate-key"
 
 	req, err := http.NewRequest("POST", url, strings.NewReader(fmt.Sprintf(`{"apiKey": "%s", "apiId": "%s"}`, apiKey, api.ID)))
@@ -295,9 +555,25 @@ func validateAPIKey(apiKey string, api *API) (string, error) {
 	return "", fmt.Errorf("invalid response format")
 }
 
+// validateJWT verifies token locally against the cached JWKS when
+// configured, falling back to the Node auth service otherwise (or if
+// local verification fails to produce a usable subject claim).
 func validateJWT(token string, api *API) (string, error) {
-	// In a real implementation, you would validate the JWT token
-	// For now, we'll make a request to your Node.js auth service
+	if jwtVerifier != nil {
+		claims, err := jwtVerifier.Verify(token)
+		if err == nil {
+			if sub, ok := claims["sub"].(string); ok && sub != "" {
+				return sub, nil
+			}
+		}
+	}
+
+	return validateJWTRemote(token)
+}
+
+// validateJWTRemote is the Node auth service fallback used when no
+// JWKS is configured, or local verification can't confirm the token.
+func validateJWTRemote(token string) (string, error) {
 	url := "http://localhost:5000/api/auth/validate-token"
 
 	req, err := http.NewRequest("POST", url, strings.NewReader(fmt.Sprintf(`{"token": "%s"}`, token)))
@@ -331,45 +607,111 @@ func validateJWT(token string, api *API) (string, error) {
 	return "", fmt.Errorf("invalid response format")
 }
 
+// forwardRequest proxies to the executor behind a per-API circuit
+// breaker, retrying idempotent methods on failure. The request body is
+// buffered up front so each retry attempt gets its own fresh reader.
 func forwardRequest(c *gin.Context, api *API, endpoint *Endpoint) (*http.Response, error) {
-	// Construct executor URL
+	ctx, span := telemetry.Tracer().Start(c.Request.Context(), "forwardRequest")
+	defer span.End()
+
 	executorURL := fmt.Sprintf("%s/execute/%s%s", config.ExecutorURL, api.ID, c.Request.URL.Path)
 
-	// Create new request
-	req, err := http.NewRequest(c.Request.Method, executorURL, c.Request.Body)
+	bodyBytes, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	// Copy headers
-	for key, values := range c.Request.Header {
-		for _, value := range values {
-			req.Header.Add(key, value)
+	var pathParamsHeader string
+	if params, ok := c.Get("pathParams"); ok {
+		if m, ok := params.(map[string]string); ok && len(m) > 0 {
+			if encoded, err := json.Marshal(m); err == nil {
+				pathParamsHeader = string(encoded)
+			}
 		}
 	}
 
-	// Add API metadata headers
-	req.Header.Set("X-API-ID", api.ID)
-	req.Header.Set("X-API-Version", api.CurrentVersion)
-	req.Header.Set("X-Endpoint-Price", fmt.Sprintf("%d", endpoint.Price))
+	buildRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(c.Request.Method, executorURL, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, err
+		}
+
+		for key, values := range c.Request.Header {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+
+		req.Header.Set("X-API-ID", api.ID)
+		req.Header.Set("X-API-Version", api.CurrentVersion)
+		req.Header.Set("X-Endpoint-Price", fmt.Sprintf("%d", endpoint.Price))
+		if pathParamsHeader != "" {
+			req.Header.Set("X-Path-Params", pathParamsHeader)
+		}
+		telemetry.InjectTraceparent(ctx, req.Header)
+
+		return req, nil
+	}
 
-	// Make request
-	client := &http.Client{Timeout: 30 * time.Second}
-	return client.Do(req)
+	return executorClient.Do(api.ID, c.Request.Method, buildRequest)
 }
 
-func logRequest(logEntry RequestLog) {
-	// Log to Redis for real-time analytics
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// runCreditReconciler periodically rolls up per-(user, API) monthly
+// credit usage tracked in Redis into MongoDB for billing, so usage
+// survives Redis restarts and is queryable alongside the rest of an
+// API's metadata. It runs until ctx is cancelled.
+func runCreditReconciler(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reconcileCreditUsage(ctx)
+		}
+	}
+}
 
-	logData, _ := json.Marshal(logEntry)
-	redisClient.LPush(ctx, "api_requests", logData)
+func reconcileCreditUsage(ctx context.Context) {
+	usage := mongoClient.Database("api_auth_service").Collection("usage")
 
-	// Also log to console for debugging
-	log.Printf("Request: %s %s %s - Status: %d - Time: %dms",
-		logEntry.Method, logEntry.APIID, logEntry.Endpoint,
-		logEntry.Status, logEntry.ResponseTime)
+	var cursor uint64
+	for {
+		keys, next, err := redisClient.Scan(ctx, cursor, "ratelimit:credits:*", 100).Result()
+		if err != nil {
+			log.Printf("credit reconciler: scan failed: %v", err)
+			return
+		}
+
+		for _, key := range keys {
+			parts := strings.Split(key, ":")
+			if len(parts) != 5 {
+				continue
+			}
+			userID, apiID, month := parts[2], parts[3], parts[4]
+
+			used, err := redisClient.Get(ctx, key).Int64()
+			if err != nil {
+				continue
+			}
+
+			_, err = usage.UpdateOne(ctx,
+				bson.M{"userId": userID, "apiId": apiID, "month": month},
+				bson.M{"$set": bson.M{"creditsUsed": used, "updatedAt": time.Now()}},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil {
+				log.Printf("credit reconciler: failed to upsert usage for %s/%s: %v", userID, apiID, err)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,159 @@
+// Package middleware holds cross-cutting checks applied to proxied
+// requests after authentication, such as rate limiting and quota
+// enforcement.
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Limits is a per-API rate/quota configuration, read from the API's
+// RateLimit document.
+type Limits struct {
+	RequestsPerMinute int
+	RequestsPerDay    int
+	MonthlyCredits    int
+}
+
+// Result is the outcome of a rate-limit check.
+type Result struct {
+	Allowed   bool
+	Remaining int64
+	ResetSecs int64
+}
+
+// checkAndIncrScript atomically checks a caller's request rate over a
+// true sliding window (a per-caller sorted set of request timestamps,
+// trimmed to the window on every call) for both the minute and day
+// granularities, plus their monthly credit usage, and admits the
+// request only if every limit is satisfied. Doing this in Lua avoids a
+// check-then-increment race between concurrent requests from the same
+// caller, and the sorted-set log (rather than a calendar-bucketed
+// counter) means a caller can never burst past the limit by straddling
+// a window boundary.
+const checkAndIncrScript = `
+local now_ms = tonumber(ARGV[5])
+local minute_window_ms = tonumber(ARGV[6])
+local day_window_ms = tonumber(ARGV[7])
+local credits_ttl = tonumber(ARGV[8])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now_ms - minute_window_ms)
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', now_ms - day_window_ms)
+
+local minute_count = redis.call('ZCARD', KEYS[1])
+local day_count = redis.call('ZCARD', KEYS[2])
+local credits_used = tonumber(redis.call('GET', KEYS[3]) or '0')
+
+local minute_limit = tonumber(ARGV[1])
+local day_limit = tonumber(ARGV[2])
+local monthly_budget = tonumber(ARGV[3])
+local price = tonumber(ARGV[4])
+
+local function reset_secs(key, window_ms)
+  local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+  if oldest[2] == nil then
+    return 0
+  end
+  local remaining_ms = tonumber(oldest[2]) + window_ms - now_ms
+  if remaining_ms < 0 then remaining_ms = 0 end
+  return math.ceil(remaining_ms / 1000)
+end
+
+if minute_limit > 0 and minute_count >= minute_limit then
+  return {0, minute_limit - minute_count, reset_secs(KEYS[1], minute_window_ms)}
+end
+
+if day_limit > 0 and day_count >= day_limit then
+  return {0, day_limit - day_count, reset_secs(KEYS[2], day_window_ms)}
+end
+
+if monthly_budget > 0 and (credits_used + price) > monthly_budget then
+  return {0, monthly_budget - credits_used, 0}
+end
+
+local member = now_ms .. '-' .. math.random(1, 1000000000)
+redis.call('ZADD', KEYS[1], now_ms, member)
+redis.call('PEXPIRE', KEYS[1], minute_window_ms)
+redis.call('ZADD', KEYS[2], now_ms, member)
+redis.call('PEXPIRE', KEYS[2], day_window_ms)
+redis.call('INCRBY', KEYS[3], price)
+redis.call('EXPIRE', KEYS[3], credits_ttl)
+
+local remaining = minute_limit - minute_count - 1
+if minute_limit <= 0 then
+  remaining = -1
+end
+return {1, remaining, math.ceil(minute_window_ms / 1000)}
+`
+
+// RateLimiter enforces a per-(userID, apiID) sliding request-rate limit
+// and a monthly credit budget, backed by Redis.
+type RateLimiter struct {
+	redisClient *redis.Client
+	script      *redis.Script
+}
+
+// NewRateLimiter creates a rate limiter backed by redisClient.
+func NewRateLimiter(redisClient *redis.Client) *RateLimiter {
+	return &RateLimiter{
+		redisClient: redisClient,
+		script:      redis.NewScript(checkAndIncrScript),
+	}
+}
+
+// creditsKeyTTL bounds how long a (userID, apiID, month) credits
+// counter lives in Redis after reconcileCreditUsage has had a chance to
+// roll it into Mongo, so spent months don't accumulate forever.
+const creditsKeyTTL = 40 * 24 * time.Hour
+
+// minuteWindow and dayWindow are the sliding-window durations for the
+// two rate-limit granularities.
+const (
+	minuteWindow = time.Minute
+	dayWindow    = 24 * time.Hour
+)
+
+// Allow checks and, if permitted, atomically charges one request (and
+// `price` credits) against userID's limits for apiID. limits with a
+// zero field are treated as unlimited for that dimension.
+func (r *RateLimiter) Allow(ctx context.Context, userID, apiID string, limits Limits, price int) (*Result, error) {
+	now := time.Now()
+	minuteKey := "ratelimit:minute:" + userID + ":" + apiID
+	dayKey := "ratelimit:day:" + userID + ":" + apiID
+	creditsKey := "ratelimit:credits:" + userID + ":" + apiID + ":" + now.Format("200601")
+
+	res, err := r.script.Run(ctx, r.redisClient, []string{minuteKey, dayKey, creditsKey},
+		limits.RequestsPerMinute, limits.RequestsPerDay, limits.MonthlyCredits, price,
+		now.UnixMilli(), minuteWindow.Milliseconds(), dayWindow.Milliseconds(), int(creditsKeyTTL/time.Second),
+	).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 3 {
+		return &Result{Allowed: true}, nil
+	}
+
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	resetSecs, _ := values[2].(int64)
+
+	return &Result{
+		Allowed:   allowed == 1,
+		Remaining: remaining,
+		ResetSecs: resetSecs,
+	}, nil
+}
+
+// ReconcileCredits rolls the current month's used credits for
+// (userID, apiID) into the value that the billing pipeline should
+// persist to MongoDB. It returns the usage so the caller can upsert it;
+// this package has no MongoDB dependency of its own.
+func (r *RateLimiter) ReconcileCredits(ctx context.Context, userID, apiID string) (int64, error) {
+	creditsKey := "ratelimit:credits:" + userID + ":" + apiID + ":" + time.Now().Format("200601")
+	return r.redisClient.Get(ctx, creditsKey).Int64()
+}
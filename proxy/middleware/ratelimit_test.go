@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestLimitsZeroMeansUnlimited(t *testing.T) {
+	limits := Limits{}
+	if limits.RequestsPerMinute != 0 || limits.RequestsPerDay != 0 || limits.MonthlyCredits != 0 {
+		t.Fatalf("expected zero-value Limits to represent unlimited on every dimension")
+	}
+}
+
+func TestNewRateLimiterUsesSingleScriptInstance(t *testing.T) {
+	rl := NewRateLimiter(nil)
+	if rl.script == nil {
+		t.Fatalf("expected the check-and-increment script to be compiled on construction")
+	}
+}
+
+func newTestRateLimiter(t *testing.T) (*RateLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	return NewRateLimiter(client), s
+}
+
+func TestAllowAdmitsUpToTheMinuteLimit(t *testing.T) {
+	rl, _ := newTestRateLimiter(t)
+	limits := Limits{RequestsPerMinute: 2}
+
+	for i := 0; i < 2; i++ {
+		res, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !res.Allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	res, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Allowed {
+		t.Fatalf("expected the third request within the minute to be rejected")
+	}
+}
+
+func TestAllowSlidesRatherThanResettingOnWindowBoundary(t *testing.T) {
+	rl, s := newTestRateLimiter(t)
+	limits := Limits{RequestsPerMinute: 1}
+
+	if res, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 0); err != nil || !res.Allowed {
+		t.Fatalf("expected the first request to be allowed, got %+v, err=%v", res, err)
+	}
+
+	// FastForward only advances miniredis's key-expiry clock; Allow
+	// timestamps entries with time.Now(). After 30s the minute key's
+	// 60s PEXPIRE has not lapsed, so the earlier request still counts.
+	s.FastForward(30 * time.Second)
+
+	if res, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 0); err != nil || res.Allowed {
+		t.Fatalf("expected the request 30s later to still be rejected, got %+v, err=%v", res, err)
+	}
+
+	s.FastForward(31 * time.Second)
+
+	if res, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 0); err != nil || !res.Allowed {
+		t.Fatalf("expected the request after a full minute to be allowed, got %+v, err=%v", res, err)
+	}
+}
+
+func TestAllowRejectsOverMonthlyCreditBudget(t *testing.T) {
+	rl, _ := newTestRateLimiter(t)
+	limits := Limits{MonthlyCredits: 10}
+
+	if res, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 6); err != nil || !res.Allowed {
+		t.Fatalf("expected a 6-credit request to be allowed, got %+v, err=%v", res, err)
+	}
+
+	res, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 6)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Allowed {
+		t.Fatalf("expected a second 6-credit request to exceed the 10-credit budget")
+	}
+}
+
+func TestAllowSetsTTLOnCreditsKey(t *testing.T) {
+	rl, s := newTestRateLimiter(t)
+	limits := Limits{MonthlyCredits: 10}
+
+	if _, err := rl.Allow(context.Background(), "user-1", "api-1", limits, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	creditsKey := "ratelimit:credits:user-1:api-1:" + time.Now().Format("200601")
+	ttl := s.TTL(creditsKey)
+	if ttl <= 0 {
+		t.Fatalf("expected the credits key to have a TTL set, got %v", ttl)
+	}
+}
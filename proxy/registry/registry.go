@@ -0,0 +1,253 @@
+// Package registry keeps an in-memory snapshot of API metadata in sync
+// with MongoDB, so the proxy hot path never has to hit the database on
+// every request. It mirrors the service-registry hot-reload pattern used
+// by gateways like Tyk and micro: load once on startup, then stay fresh
+// via a MongoDB change stream plus a Redis pub/sub channel that other
+// services can publish invalidations to.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvalidateChannel is the Redis pub/sub channel other services (e.g. the
+// Node auth service) publish to when an API document changes out of band.
+const InvalidateChannel = "api:invalidate"
+
+// document is the minimal shape the registry needs out of every API
+// document to index and invalidate it; the full document is kept as raw
+// BSON so callers can decode it into whatever struct they use.
+type document struct {
+	ID   string `bson:"_id"`
+	Name string `bson:"name"`
+}
+
+// Registry is an in-memory, concurrency-safe snapshot of the `apis`
+// collection, kept current by a MongoDB change stream and Redis
+// invalidation messages.
+type Registry struct {
+	collection *mongo.Collection
+	redis      *redis.Client
+
+	mu     sync.RWMutex
+	byName map[string]bson.Raw
+
+	// OnInvalidate is called whenever an API's metadata changes, with
+	// the affected API ID, so dependent caches (e.g. a compiled route
+	// table) can be dropped. It may be nil.
+	OnInvalidate func(apiID string)
+}
+
+// New creates a registry backed by collection and redisClient. Call
+// Resync once to populate it, then WatchChangeStream and
+// WatchInvalidations to keep it current.
+func New(collection *mongo.Collection, redisClient *redis.Client) *Registry {
+	return &Registry{
+		collection: collection,
+		redis:      redisClient,
+		byName:     make(map[string]bson.Raw),
+	}
+}
+
+// Get returns the raw BSON for apiName, if cached.
+func (r *Registry) Get(apiName string) (bson.Raw, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	raw, ok := r.byName[apiName]
+	return raw, ok
+}
+
+// Decode looks up apiName and unmarshals it into out (typically an
+// *API pointer owned by the caller).
+func (r *Registry) Decode(apiName string, out interface{}) (bool, error) {
+	raw, ok := r.Get(apiName)
+	if !ok {
+		return false, nil
+	}
+	if err := bson.Unmarshal(raw, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Resync does a full reload of every API document from MongoDB,
+// replacing the in-memory map atomically, and fires OnInvalidate for
+// every API whose document actually changed (added, edited, or
+// removed) so dependent caches - like a compiled route table - don't
+// keep serving a stale entry for an API that changed without its own
+// pub/sub notification.
+func (r *Registry) Resync(ctx context.Context) error {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	fresh := make(map[string]bson.Raw)
+	for cursor.Next(ctx) {
+		raw := append(bson.Raw{}, cursor.Current...)
+		var doc document
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			continue
+		}
+		fresh[doc.Name] = raw
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	previous := r.byName
+	r.byName = fresh
+	r.mu.Unlock()
+
+	log.Printf("MetadataRegistry: resynced %d APIs", len(fresh))
+
+	if r.OnInvalidate != nil {
+		for _, apiID := range changedIDs(previous, fresh) {
+			r.OnInvalidate(apiID)
+		}
+	}
+	return nil
+}
+
+// changedIDs compares two name->document snapshots and returns the IDs
+// of every document that was added, edited, or removed between them.
+func changedIDs(previous, fresh map[string]bson.Raw) []string {
+	var ids []string
+	seen := make(map[string]bool)
+
+	add := func(raw bson.Raw) {
+		var doc document
+		if err := bson.Unmarshal(raw, &doc); err == nil && !seen[doc.ID] {
+			seen[doc.ID] = true
+			ids = append(ids, doc.ID)
+		}
+	}
+
+	for name, raw := range fresh {
+		if old, ok := previous[name]; !ok || !bytes.Equal(old, raw) {
+			add(raw)
+		}
+	}
+	for name, raw := range previous {
+		if _, ok := fresh[name]; !ok {
+			add(raw)
+		}
+	}
+	return ids
+}
+
+// upsert updates the cached entry for a single document and fires
+// OnInvalidate for its ID.
+func (r *Registry) upsert(raw bson.Raw) {
+	var doc document
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.byName[doc.Name] = raw
+	r.mu.Unlock()
+
+	if r.OnInvalidate != nil {
+		r.OnInvalidate(doc.ID)
+	}
+}
+
+// remove drops a document by ID and fires OnInvalidate.
+func (r *Registry) remove(apiID string) {
+	r.mu.Lock()
+	for name, raw := range r.byName {
+		var doc document
+		if err := bson.Unmarshal(raw, &doc); err == nil && doc.ID == apiID {
+			delete(r.byName, name)
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if r.OnInvalidate != nil {
+		r.OnInvalidate(apiID)
+	}
+}
+
+// WatchChangeStream subscribes to the `apis` collection's change stream
+// and applies inserts/updates/replaces/deletes to the in-memory map as
+// they happen. It blocks until ctx is cancelled or the stream errors, so
+// callers should run it in its own goroutine.
+func (r *Registry) WatchChangeStream(ctx context.Context) {
+	// MongoDB only populates fullDocument by default for insert/replace
+	// (deletes are handled via documentKey below); a plain update (the common admin-UI edit path, e.g. a
+	// $set on isEnabled or rateLimit) would otherwise arrive with an
+	// empty fullDocument and be silently dropped below.
+	streamOpts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	stream, err := r.collection.Watch(ctx, mongo.Pipeline{}, streamOpts)
+	if err != nil {
+		log.Printf("MetadataRegistry: failed to open change stream: %v", err)
+		return
+	}
+	defer stream.Close(ctx)
+
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType string   `bson:"operationType"`
+			FullDocument  bson.Raw `bson:"fullDocument"`
+			DocumentKey   struct {
+				ID string `bson:"_id"`
+			} `bson:"documentKey"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			log.Printf("MetadataRegistry: failed to decode change event: %v", err)
+			continue
+		}
+
+		switch event.OperationType {
+		case "insert", "update", "replace":
+			if len(event.FullDocument) > 0 {
+				r.upsert(event.FullDocument)
+			}
+		case "delete":
+			r.remove(event.DocumentKey.ID)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		log.Printf("MetadataRegistry: change stream closed with error: %v", err)
+	}
+}
+
+// WatchInvalidations subscribes to the Redis InvalidateChannel so other
+// services (e.g. the Node auth service) can push a resync without
+// waiting on the change stream. Any message - a single API ID or "*" -
+// triggers a full Resync, which itself diffs against the previous
+// snapshot and fires OnInvalidate for every API that actually changed,
+// so a document edited without its own pub/sub message still gets its
+// dependent caches invalidated. It blocks until ctx is cancelled, so
+// callers should run it in its own goroutine.
+func (r *Registry) WatchInvalidations(ctx context.Context) {
+	sub := r.redis.Subscribe(ctx, InvalidateChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := r.Resync(ctx); err != nil {
+				log.Printf("MetadataRegistry: resync after invalidation failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUpsertAndGet(t *testing.T) {
+	r := &Registry{byName: make(map[string]bson.Raw)}
+
+	raw, err := bson.Marshal(bson.M{"_id": "api-1", "name": "test-api"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var invalidated string
+	r.OnInvalidate = func(apiID string) { invalidated = apiID }
+
+	r.upsert(raw)
+
+	if _, ok := r.Get("test-api"); !ok {
+		t.Fatalf("expected test-api to be cached")
+	}
+	if invalidated != "api-1" {
+		t.Fatalf("expected OnInvalidate to fire with api-1, got %q", invalidated)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	r := &Registry{byName: make(map[string]bson.Raw)}
+	raw, _ := bson.Marshal(bson.M{"_id": "api-1", "name": "test-api"})
+	r.upsert(raw)
+
+	r.remove("api-1")
+
+	if _, ok := r.Get("test-api"); ok {
+		t.Fatalf("expected test-api to be evicted after remove")
+	}
+}
+
+func TestChangedIDsCatchesEditsAddsAndRemoves(t *testing.T) {
+	unchanged, _ := bson.Marshal(bson.M{"_id": "api-1", "name": "unchanged-api"})
+	editedBefore, _ := bson.Marshal(bson.M{"_id": "api-2", "name": "edited-api", "rev": 1})
+	editedAfter, _ := bson.Marshal(bson.M{"_id": "api-2", "name": "edited-api", "rev": 2})
+	removed, _ := bson.Marshal(bson.M{"_id": "api-3", "name": "removed-api"})
+	added, _ := bson.Marshal(bson.M{"_id": "api-4", "name": "added-api"})
+
+	previous := map[string]bson.Raw{
+		"unchanged-api": unchanged,
+		"edited-api":    editedBefore,
+		"removed-api":   removed,
+	}
+	fresh := map[string]bson.Raw{
+		"unchanged-api": unchanged,
+		"edited-api":    editedAfter,
+		"added-api":     added,
+	}
+
+	ids := changedIDs(previous, fresh)
+
+	got := make(map[string]bool)
+	for _, id := range ids {
+		got[id] = true
+	}
+	if got["api-1"] {
+		t.Fatalf("expected the unchanged API not to be reported, got %v", ids)
+	}
+	if !got["api-2"] {
+		t.Fatalf("expected the edited API's ID to be reported, got %v", ids)
+	}
+	if !got["api-3"] {
+		t.Fatalf("expected the removed API's ID to be reported, got %v", ids)
+	}
+	if !got["api-4"] {
+		t.Fatalf("expected the added API's ID to be reported, got %v", ids)
+	}
+}
@@ -0,0 +1,162 @@
+// Package router compiles API endpoint declarations into matchable route
+// patterns, so proxy lookups support path parameters (":id") and wildcards
+// ("*rest") instead of strict string equality.
+package router
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Route is a single compiled endpoint pattern.
+type Route struct {
+	Method   string
+	Host     string
+	Raw      string
+	regex    *regexp.Regexp
+	paramIDs []string
+	data     interface{}
+}
+
+// Data returns the value the route was compiled from (typically an
+// *Endpoint), so callers can recover it after a match without a second
+// lookup.
+func (r *Route) Data() interface{} {
+	return r.data
+}
+
+// Router matches incoming requests against the routes compiled for a
+// single API.
+type Router struct {
+	routes []*Route
+}
+
+// New compiles routes from the given endpoints. Callers convert their own
+// endpoint type into EndpointSource values (method, path, host, data) so
+// this package doesn't need to depend on the caller's Endpoint type.
+func New(endpoints []EndpointSource) *Router {
+	r := &Router{routes: make([]*Route, 0, len(endpoints))}
+	for _, ep := range endpoints {
+		r.routes = append(r.routes, compile(ep))
+	}
+	return r
+}
+
+// EndpointSource is the minimal shape a Route can be compiled from.
+type EndpointSource struct {
+	Method string
+	Path   string
+	Host   string
+	Data   interface{}
+}
+
+func compile(ep EndpointSource) *Route {
+	segments := strings.Split(strings.Trim(ep.Path, "/"), "/")
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	var paramIDs []string
+	for i, seg := range segments {
+		if i > 0 {
+			pattern.WriteString("/")
+		}
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			paramIDs = append(paramIDs, seg[1:])
+			pattern.WriteString("([^/]+)")
+		case seg == "*" || strings.HasPrefix(seg, "*"):
+			name := strings.TrimPrefix(seg, "*")
+			if name == "" {
+				name = "wildcard"
+			}
+			paramIDs = append(paramIDs, name)
+			pattern.WriteString("(.*)")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(seg))
+		}
+	}
+	pattern.WriteString("$")
+
+	return &Route{
+		Method:   ep.Method,
+		Host:     ep.Host,
+		Raw:      ep.Path,
+		regex:    regexp.MustCompile(pattern.String()),
+		paramIDs: paramIDs,
+		data:     ep.Data,
+	}
+}
+
+// Match finds the first route matching method, host and path, returning
+// the route's Data and the extracted path parameters.
+func (r *Router) Match(method, host, path string) (interface{}, map[string]string, bool) {
+	path = strings.Trim(path, "/")
+	for _, route := range r.routes {
+		if route.Method != method {
+			continue
+		}
+		if route.Host != "" && !strings.EqualFold(route.Host, host) {
+			continue
+		}
+		m := route.regex.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		params := make(map[string]string, len(route.paramIDs))
+		for i, id := range route.paramIDs {
+			params[id] = m[i+1]
+		}
+		return route.data, params, true
+	}
+	return nil, nil, false
+}
+
+// Cache holds one compiled Router per API ID so repeated proxy hits don't
+// recompile patterns on every request. It is invalidated whenever the
+// underlying API metadata changes.
+type Cache struct {
+	mu      sync.RWMutex
+	routers map[string]*Router
+}
+
+// NewCache creates an empty router cache.
+func NewCache() *Cache {
+	return &Cache{routers: make(map[string]*Router)}
+}
+
+// GetOrCompile returns the cached Router for apiID, compiling and storing
+// one via build if it isn't cached yet.
+func (c *Cache) GetOrCompile(apiID string, build func() []EndpointSource) *Router {
+	c.mu.RLock()
+	if r, ok := c.routers[apiID]; ok {
+		c.mu.RUnlock()
+		return r
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r, ok := c.routers[apiID]; ok {
+		return r
+	}
+	r := New(build())
+	c.routers[apiID] = r
+	return r
+}
+
+// Invalidate drops the cached Router for apiID, forcing the next
+// GetOrCompile to rebuild it from fresh metadata.
+func (c *Cache) Invalidate(apiID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.routers, apiID)
+}
+
+// InvalidateAll drops every cached Router, used after a full metadata
+// resync.
+func (c *Cache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.routers = make(map[string]*Router)
+}
@@ -0,0 +1,66 @@
+package router
+
+import "testing"
+
+func TestMatchPathParams(t *testing.T) {
+	r := New([]EndpointSource{
+		{Method: "GET", Path: "/users/:id", Data: "get-user"},
+		{Method: "GET", Path: "/orders/*rest", Data: "get-orders"},
+	})
+
+	data, params, found := r.Match("GET", "", "/users/42")
+	if !found {
+		t.Fatalf("expected match for /users/42")
+	}
+	if data.(string) != "get-user" {
+		t.Fatalf("expected get-user, got %v", data)
+	}
+	if params["id"] != "42" {
+		t.Fatalf("expected id=42, got %v", params)
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	r := New([]EndpointSource{
+		{Method: "GET", Path: "/orders/*rest", Data: "get-orders"},
+	})
+
+	_, params, found := r.Match("GET", "", "/orders/a/b/c")
+	if !found {
+		t.Fatalf("expected match for /orders/a/b/c")
+	}
+	if params["rest"] != "a/b/c" {
+		t.Fatalf("expected rest=a/b/c, got %v", params)
+	}
+}
+
+func TestMatchNoMatch(t *testing.T) {
+	r := New([]EndpointSource{
+		{Method: "GET", Path: "/users/:id", Data: "get-user"},
+	})
+
+	if _, _, found := r.Match("POST", "", "/users/42"); found {
+		t.Fatalf("expected no match for wrong method")
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c := NewCache()
+	calls := 0
+	build := func() []EndpointSource {
+		calls++
+		return []EndpointSource{{Method: "GET", Path: "/x", Data: "x"}}
+	}
+
+	c.GetOrCompile("api-1", build)
+	c.GetOrCompile("api-1", build)
+	if calls != 1 {
+		t.Fatalf("expected build to run once before invalidation, ran %d times", calls)
+	}
+
+	c.Invalidate("api-1")
+	c.GetOrCompile("api-1", build)
+	if calls != 2 {
+		t.Fatalf("expected build to re-run after invalidation, ran %d times", calls)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -24,48 +26,156 @@ type RequestLog struct {
 	ResponseSize int64     `json:"response_size"`
 }
 
-// LoggerService handles request logging
+const (
+	// recordsChanSize bounds how many pending log records the proxy can
+	// queue before LogRequest starts dropping, giving the worker pool
+	// room to absorb bursts without blocking the request path.
+	recordsChanSize = 1000
+	// batchSize is the max number of records flushed in a single Redis
+	// pipeline round trip.
+	batchSize = 200
+	// flushInterval caps how long a partial batch waits before being
+	// flushed anyway.
+	flushInterval = 200 * time.Millisecond
+	// workerCount is the number of goroutines draining recordsChan.
+	workerCount = 2
+)
+
+// LoggerService batches request logs into Redis pipelines instead of
+// issuing a round trip per request. Records are handed off over a
+// bounded channel; a small worker pool drains it, flushing whenever a
+// batch fills up or flushInterval elapses, whichever comes first.
 type LoggerService struct {
 	redisClient *redis.Client
+	recordsChan chan *RequestLog
+
+	dropped uint64
+
+	wg     sync.WaitGroup
+	stopCh chan struct{}
 }
 
-// NewLoggerService creates a new logger service
+// NewLoggerService creates a logger service and starts its worker pool.
+// Call Close to flush pending records and stop the workers.
 func NewLoggerService(redisClient *redis.Client) *LoggerService {
-	return &LoggerService{
+	l := &LoggerService{
 		redisClient: redisClient,
+		recordsChan: make(chan *RequestLog, recordsChanSize),
+		stopCh:      make(chan struct{}),
 	}
+
+	l.wg.Add(workerCount)
+	for i := 0; i < workerCount; i++ {
+		go l.worker()
+	}
+
+	return l
 }
 
-// LogRequest logs a request to Redis and console
+// LogRequest enqueues a request log for batched flushing. It never
+// blocks: if the buffer is full the record is dropped and counted, so a
+// slow Redis can't back up the proxy's request path.
 func (l *LoggerService) LogRequest(logEntry RequestLog) {
-	// Log to Redis for real-time analytics
+	select {
+	case l.recordsChan <- &logEntry:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+	}
+}
+
+// DroppedCount returns how many records have been dropped because the
+// buffer was full, for exposing on /health.
+func (l *LoggerService) DroppedCount() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+// Close signals the worker pool to stop, flushes whatever is left in the
+// buffer, and waits for the workers to exit. LogRequest does not check
+// for Close: records logged after it returns are buffered (or dropped
+// once the buffer is full) and never flushed.
+func (l *LoggerService) Close() {
+	close(l.stopCh)
+	l.wg.Wait()
+}
+
+func (l *LoggerService) worker() {
+	defer l.wg.Done()
+
+	batch := make([]*RequestLog, 0, batchSize)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		l.flushBatch(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry := <-l.recordsChan:
+			batch = append(batch, entry)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-l.stopCh:
+			// Drain whatever is already queued before exiting.
+			for {
+				select {
+				case entry := <-l.recordsChan:
+					batch = append(batch, entry)
+					if len(batch) >= batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flushBatch writes a batch of records in a single Redis pipeline:
+// LPUSH + LTRIM on the recent-requests list, and HINCRBY for daily/hourly
+// stats, instead of one round trip per record.
+func (l *LoggerService) flushBatch(batch []*RequestLog) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	logData, _ := json.Marshal(logEntry)
+	pipe := l.redisClient.Pipeline()
 
-	// Store in Redis list for recent requests
-	l.redisClient.LPush(ctx, "api_requests", logData)
+	for _, logEntry := range batch {
+		logData, err := json.Marshal(logEntry)
+		if err != nil {
+			continue
+		}
 
-	// Keep only last 1000 requests
-	l.redisClient.LTrim(ctx, "api_requests", 0, 999)
+		pipe.LPush(ctx, "api_requests", logData)
 
-	// Store in Redis hash for analytics
-	dateKey := logEntry.Timestamp.Format("2006-01-02")
-	hourKey := logEntry.Timestamp.Format("15")
+		dateKey := logEntry.Timestamp.Format("2006-01-02")
+		hourKey := logEntry.Timestamp.Format("15")
 
-	// Increment daily stats
-	l.redisClient.HIncrBy(ctx, "stats:daily:"+dateKey, "total_requests", 1)
-	l.redisClient.HIncrBy(ctx, "stats:daily:"+dateKey, "total_response_time", logEntry.ResponseTime)
+		pipe.HIncrBy(ctx, "stats:daily:"+dateKey, "total_requests", 1)
+		pipe.HIncrBy(ctx, "stats:daily:"+dateKey, "total_response_time", logEntry.ResponseTime)
+		pipe.HIncrBy(ctx, "stats:hourly:"+dateKey+":"+hourKey, "total_requests", 1)
+		pipe.HIncrBy(ctx, "stats:hourly:"+dateKey+":"+hourKey, "total_response_time", logEntry.ResponseTime)
 
-	// Increment hourly stats
-	l.redisClient.HIncrBy(ctx, "stats:hourly:"+dateKey+":"+hourKey, "total_requests", 1)
-	l.redisClient.HIncrBy(ctx, "stats:hourly:"+dateKey+":"+hourKey, "total_response_time", logEntry.ResponseTime)
+		log.Printf("Request: %s %s %s - User: %s - Status: %d - Time: %dms - IP: %s",
+			logEntry.Method, logEntry.APIID, logEntry.Endpoint,
+			logEntry.UserID, logEntry.Status, logEntry.ResponseTime, logEntry.IP)
+	}
+
+	// Keep only the last 1000 requests after this batch's pushes.
+	pipe.LTrim(ctx, "api_requests", 0, 999)
 
-	// Log to console for debugging
-	log.Printf("Request: %s %s %s - User: %s - Status: %d - Time: %dms - IP: %s",
-		logEntry.Method, logEntry.APIID, logEntry.Endpoint,
-		logEntry.UserID, logEntry.Status, logEntry.ResponseTime, logEntry.IP)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("LoggerService: failed to flush batch of %d records: %v", len(batch), err)
+	}
 }
 
 // GetRecentRequests gets recent requests from Redis
@@ -0,0 +1,18 @@
+package services
+
+import "testing"
+
+func TestLogRequestDropsWhenBufferFull(t *testing.T) {
+	l := &LoggerService{
+		recordsChan: make(chan *RequestLog, 2),
+		stopCh:      make(chan struct{}),
+	}
+
+	for i := 0; i < 5; i++ {
+		l.LogRequest(RequestLog{UserID: "u"})
+	}
+
+	if got := l.DroppedCount(); got == 0 {
+		t.Fatalf("expected some records to be dropped once the buffer filled, got 0")
+	}
+}
@@ -0,0 +1,76 @@
+// Package telemetry wires up the gateway's observability surface:
+// Prometheus metrics for /metrics and an OpenTelemetry tracer for
+// end-to-end request tracing into the executor.
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// RequestDuration tracks end-to-end proxy latency per API/endpoint.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_request_duration_seconds",
+		Help:    "Proxy request duration in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"api_id", "endpoint", "method", "status"})
+
+	// AuthFailures counts rejected authentication attempts by reason.
+	AuthFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_auth_failures_total",
+		Help: "Total authentication failures",
+	}, []string{"reason"})
+
+	// RateLimitRejections counts 429s by API.
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_rate_limit_rejections_total",
+		Help: "Total requests rejected for exceeding a rate limit or quota",
+	}, []string{"api_id"})
+
+	// CircuitBreakerTrips counts transitions into the open state, by API.
+	CircuitBreakerTrips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_circuit_breaker_trips_total",
+		Help: "Total circuit breaker trips into the open state",
+	}, []string{"api_id"})
+
+	// MongoLatency tracks MongoDB round-trip latency by operation.
+	MongoLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_mongo_latency_seconds",
+		Help:    "MongoDB operation latency in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	// RedisLatency tracks Redis round-trip latency by operation.
+	RedisLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_redis_latency_seconds",
+		Help:    "Redis operation latency in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	// JWKSVerifications counts local JWT verification outcomes against
+	// the cached JWKS document, by result (hit, miss, error).
+	JWKSVerifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_jwks_verifications_total",
+		Help: "Total local JWT verifications against the cached JWKS document, by result",
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		RequestDuration,
+		AuthFailures,
+		RateLimitRejections,
+		CircuitBreakerTrips,
+		MongoLatency,
+		RedisLatency,
+		JWKSVerifications,
+	)
+}
+
+// Handler serves the Prometheus exposition format for /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
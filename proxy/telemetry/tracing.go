@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "api-gateway/proxy"
+
+// InitTracer configures the global OpenTelemetry tracer provider to
+// export spans via OTLP/gRPC to otlpEndpoint, and installs a W3C
+// tracecontext propagator so traceparent headers stitch together with
+// the executor. It returns a shutdown func to flush pending spans on
+// exit.
+func InitTracer(ctx context.Context, serviceName, otlpEndpoint string) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(otlpEndpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the proxy's named tracer, for starting spans around
+// proxyHandler, getAPIMetadata, validateAuth, and forwardRequest.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// InjectTraceparent writes the current span context from ctx into
+// header as a traceparent (and optional tracestate) header, so the
+// executor can continue the same trace.
+func InjectTraceparent(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}